@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// fatalFlushTimeout bounds how long Logger.Fatal/Panic (and their f/w
+// variants) wait for async backends to drain before exiting/panicking.
+const fatalFlushTimeout = 5 * time.Second
+
+// OverflowPolicy controls what an async Worker (see NewAsyncWorker) does
+// when its queue is full.
+type OverflowPolicy int
+
+// Overflow policies for NewAsyncWorker.
+const (
+	// Block makes the caller wait for room in the queue.
+	Block OverflowPolicy = iota
+	// DropNewest discards the incoming record when the queue is full.
+	DropNewest
+	// DropOldest discards the oldest queued record to make room for the
+	// incoming one.
+	DropOldest
+)
+
+// AsyncMetrics is a point-in-time snapshot of an async Worker's queue.
+type AsyncMetrics struct {
+	Enqueued uint64
+	Dropped  uint64
+	QueueLen int
+}
+
+// flusher is implemented by Backends that buffer records and need
+// draining before the process exits; Logger.Fatal/Panic (and their f/w
+// variants) flush every registered Backend that implements it.
+type flusher interface {
+	Flush(timeout time.Duration) bool
+}
+
+// NewAsyncWorker wraps inner so that logging never blocks the caller on
+// a potentially slow writer (a file, syslog, or the network): records are
+// buffered on a bounded in-memory queue of queueSize (<= 0 is treated as
+// 1) and drained by a single background goroutine. policy controls what
+// happens once the queue is full.
+func NewAsyncWorker(inner *Worker, queueSize int, policy OverflowPolicy) *Worker {
+	aw := newAsyncWriter(inner.Minion.Writer(), queueSize, policy)
+	return &Worker{
+		Minion:     log.New(aw, "", 0),
+		Color:      inner.Color,
+		format:     inner.format,
+		timeFormat: inner.timeFormat,
+		level:      inner.level,
+		encoder:    inner.encoder,
+		async:      aw,
+	}
+}
+
+// Metrics returns a snapshot of the worker's async queue. It is the zero
+// value for a Worker not created by NewAsyncWorker.
+func (w *Worker) Metrics() AsyncMetrics {
+	if w.async == nil {
+		return AsyncMetrics{}
+	}
+	return w.async.metrics()
+}
+
+// Flush blocks until the worker's async queue drains or timeout elapses,
+// reporting whether it drained in time. It is a no-op returning true for
+// a Worker not created by NewAsyncWorker.
+func (w *Worker) Flush(timeout time.Duration) bool {
+	if w.async == nil {
+		return true
+	}
+	return w.async.flush(timeout)
+}
+
+// Close drains the worker's async queue and stops its background
+// goroutine. It is a no-op for a Worker not created by NewAsyncWorker.
+// Once closed, the worker must not be logged to again.
+func (w *Worker) Close() {
+	if w.async != nil {
+		w.async.close()
+	}
+}
+
+// asyncWriter is the io.Writer behind an async Worker's Minion: Write
+// enqueues a copy of the formatted record and returns immediately (or,
+// under the Block policy, once there's room), while run drains the
+// queue into the real writer on its own goroutine.
+type asyncWriter struct {
+	out    io.Writer
+	policy OverflowPolicy
+	max    int
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    [][]byte
+	draining bool // true from the moment run pops a record until out.Write returns
+	closed   bool
+	enqueued uint64
+	dropped  uint64
+	wg       sync.WaitGroup
+}
+
+func newAsyncWriter(out io.Writer, queueSize int, policy OverflowPolicy) *asyncWriter {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	aw := &asyncWriter{out: out, policy: policy, max: queueSize}
+	aw.cond = sync.NewCond(&aw.mu)
+	aw.wg.Add(1)
+	go aw.run()
+	return aw
+}
+
+func (aw *asyncWriter) Write(p []byte) (int, error) {
+	data := append([]byte(nil), p...)
+
+	aw.mu.Lock()
+	if aw.closed {
+		aw.mu.Unlock()
+		return 0, errors.New("logger: async worker is closed")
+	}
+	for len(aw.queue) >= aw.max {
+		switch aw.policy {
+		case DropNewest:
+			aw.dropped++
+			aw.mu.Unlock()
+			return len(p), nil
+		case DropOldest:
+			aw.queue = aw.queue[1:]
+			aw.dropped++
+		default: // Block
+			aw.cond.Wait()
+		}
+	}
+	aw.queue = append(aw.queue, data)
+	aw.enqueued++
+	aw.mu.Unlock()
+	aw.cond.Signal()
+	return len(p), nil
+}
+
+func (aw *asyncWriter) run() {
+	defer aw.wg.Done()
+	for {
+		aw.mu.Lock()
+		for len(aw.queue) == 0 && !aw.closed {
+			aw.cond.Wait()
+		}
+		if len(aw.queue) == 0 && aw.closed {
+			aw.mu.Unlock()
+			return
+		}
+		data := aw.queue[0]
+		aw.queue = aw.queue[1:]
+		aw.draining = true
+		aw.mu.Unlock()
+
+		aw.out.Write(data)
+
+		aw.mu.Lock()
+		aw.draining = false
+		aw.mu.Unlock()
+		aw.cond.Signal() // wake a Block-policy Write waiting for room; flush polls instead
+	}
+}
+
+func (aw *asyncWriter) metrics() AsyncMetrics {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	return AsyncMetrics{Enqueued: aw.enqueued, Dropped: aw.dropped, QueueLen: len(aw.queue)}
+}
+
+func (aw *asyncWriter) close() {
+	aw.mu.Lock()
+	aw.closed = true
+	aw.mu.Unlock()
+	aw.cond.Broadcast()
+	aw.wg.Wait()
+}
+
+func (aw *asyncWriter) flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		aw.mu.Lock()
+		drained := len(aw.queue) == 0 && !aw.draining
+		aw.mu.Unlock()
+		if drained {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter blocks its Write call until release is closed, so a test can
+// pin a record mid-flight inside asyncWriter.run and observe what Flush
+// does while that write is still in progress.
+type slowWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	release chan struct{}
+}
+
+func newSlowWriter() *slowWriter {
+	return &slowWriter{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriterFlushWaitsForInFlightWrite(t *testing.T) {
+	sw := newSlowWriter()
+	aw := newAsyncWriter(sw, 4, Block)
+	defer aw.close()
+
+	if _, err := aw.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	<-sw.started // run() popped the record and is now blocked inside out.Write
+
+	done := make(chan bool, 1)
+	go func() { done <- aw.flush(time.Second) }()
+
+	select {
+	case <-done:
+		t.Fatal("flush returned while the in-flight write had not completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sw.release)
+
+	if ok := <-done; !ok {
+		t.Fatal("flush timed out after the write completed")
+	}
+	if got, want := sw.String(), "hello\n"; got != want {
+		t.Fatalf("writer content = %q, want %q", got, want)
+	}
+}
+
+func TestAsyncWriterBlockPolicy(t *testing.T) {
+	var buf bytes.Buffer
+	aw := newAsyncWriter(&buf, 1, Block)
+	defer aw.close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := aw.Write([]byte("x")); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+	if !aw.flush(time.Second) {
+		t.Fatal("flush did not drain in time")
+	}
+	if m := aw.metrics(); m.Enqueued != 5 || m.Dropped != 0 || m.QueueLen != 0 {
+		t.Fatalf("metrics = %+v, want {Enqueued:5 Dropped:0 QueueLen:0}", m)
+	}
+	if got, want := buf.String(), "xxxxx"; got != want {
+		t.Fatalf("writer content = %q, want %q", got, want)
+	}
+}
+
+func TestAsyncWriterDropNewest(t *testing.T) {
+	sw := newSlowWriter()
+	aw := newAsyncWriter(sw, 1, DropNewest)
+	defer func() {
+		close(sw.release)
+		aw.close()
+	}()
+
+	aw.Write([]byte("a")) // picked up by run(), blocks inside out.Write
+	<-sw.started
+	aw.Write([]byte("b")) // fills the queue
+	aw.Write([]byte("c")) // queue full -> dropped, "b" unaffected
+
+	if m := aw.metrics(); m.Dropped != 1 || m.QueueLen != 1 {
+		t.Fatalf("metrics = %+v, want Dropped=1 QueueLen=1", m)
+	}
+}
+
+func TestAsyncWriterDropOldest(t *testing.T) {
+	sw := newSlowWriter()
+	aw := newAsyncWriter(sw, 1, DropOldest)
+	defer func() {
+		close(sw.release)
+		aw.close()
+	}()
+
+	aw.Write([]byte("a")) // picked up by run(), blocks inside out.Write
+	<-sw.started
+	aw.Write([]byte("b")) // fills the queue
+	aw.Write([]byte("c")) // queue full -> "b" evicted, "c" queued
+
+	if m := aw.metrics(); m.Dropped != 1 || m.QueueLen != 1 {
+		t.Fatalf("metrics = %+v, want Dropped=1 QueueLen=1", m)
+	}
+}
+
+// TestLoggerFatalFlushesAsyncBackendBeforeExit exercises the Fatal-flush
+// contract end to end: it re-execs this test binary as a child process
+// (Fatal calls os.Exit, so it can't run in-process) with
+// GO_LOGGER_FATAL_FLUSH_CHILD=1, and checks that the async backend's
+// record made it to disk before the child exited.
+func TestLoggerFatalFlushesAsyncBackendBeforeExit(t *testing.T) {
+	if os.Getenv("GO_LOGGER_FATAL_FLUSH_CHILD") == "1" {
+		runFatalFlushChild()
+		return
+	}
+
+	out, err := os.CreateTemp(t.TempDir(), "fatal-flush-*.log")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	out.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestLoggerFatalFlushesAsyncBackendBeforeExit")
+	cmd.Env = append(os.Environ(), "GO_LOGGER_FATAL_FLUSH_CHILD=1", "GO_LOGGER_FATAL_FLUSH_FILE="+out.Name())
+	if runErr := cmd.Run(); runErr == nil {
+		t.Fatal("child process exited 0, want exit status 1 from os.Exit(1)")
+	}
+
+	got, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Contains(got, []byte("dying message")) {
+		t.Fatalf("log file = %q, want it to contain the Fatal message", got)
+	}
+}
+
+func runFatalFlushChild() {
+	f, err := os.OpenFile(os.Getenv("GO_LOGGER_FATAL_FLUSH_FILE"), os.O_WRONLY, 0)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	l, err := New("fatal-flush-child")
+	if err != nil {
+		panic(err)
+	}
+	l.RemoveBackend("default")
+	l.AddBackend("async", NewWorkerBackend(NewAsyncWorker(NewIOBackend(f, "").worker, 16, Block)))
+	l.Fatal("dying message")
+}
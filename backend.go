@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"container/ring"
+	"io"
+	"sync"
+	"time"
+)
+
+// Backend is implemented by anything that can receive and persist a log
+// record. A Logger fans the same Info out to every Backend registered on
+// it via AddBackend.
+type Backend interface {
+	Log(level LogLevel, info *Info) error
+}
+
+// IOBackend writes formatted log records to an io.Writer, e.g. os.Stderr,
+// a rotating file, or any other io.Writer.
+type IOBackend struct {
+	worker *Worker
+}
+
+// NewIOBackend returns a Backend that writes to out using format (the
+// package default format is used when format is empty). Info.Time is
+// rendered by the Logger before any Backend sees the record, so a
+// %{time:layout} in format does not change the time layout written here.
+func NewIOBackend(out io.Writer, format string) *IOBackend {
+	worker := NewWorker("", 0, 0, out)
+	if format != "" {
+		worker.SetFormat(format)
+	}
+	return &IOBackend{worker: worker}
+}
+
+// SetEncoder selects the Encoder used to render records for this
+// backend, e.g. NewJSONEncoder() or NewLogfmtEncoder() instead of the
+// default TextEncoder.
+func (b *IOBackend) SetEncoder(e Encoder) {
+	b.worker.SetEncoder(e)
+}
+
+// SetColor enables (non-zero) or disables (0) ANSI color output for this
+// backend.
+func (b *IOBackend) SetColor(color int) {
+	b.worker.SetColor(color)
+}
+
+// Log writes info to the underlying writer.
+func (b *IOBackend) Log(level LogLevel, info *Info) error {
+	return b.worker.Log(level, 2, info)
+}
+
+// Flush satisfies the interface Fatal/Panic use to drain async backends.
+// An IOBackend's worker writes synchronously, so it returns true at once.
+func (b *IOBackend) Flush(timeout time.Duration) bool {
+	return b.worker.Flush(timeout)
+}
+
+// MemoryBackend keeps the last size records in an in-memory ring buffer,
+// useful for tests or for exposing recent log history over an API.
+type MemoryBackend struct {
+	mu  sync.Mutex
+	buf *ring.Ring
+}
+
+// NewMemoryBackend returns a Backend that retains the last size records.
+func NewMemoryBackend(size int) *MemoryBackend {
+	return &MemoryBackend{buf: ring.New(size)}
+}
+
+// Log stores info, overwriting the oldest record once the buffer is full.
+func (b *MemoryBackend) Log(level LogLevel, info *Info) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Value = info
+	b.buf = b.buf.Next()
+	return nil
+}
+
+// Records returns the buffered records in chronological order.
+func (b *MemoryBackend) Records() []*Info {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	records := make([]*Info, 0, b.buf.Len())
+	b.buf.Do(func(v interface{}) {
+		if v == nil {
+			return
+		}
+		records = append(records, v.(*Info))
+	})
+	return records
+}
+
+// LeveledBackend wraps a Backend and drops any record below its configured
+// minimum LogLevel, so the same Backend type can run at different
+// verbosities for different loggers.
+type LeveledBackend struct {
+	backend Backend
+	level   LogLevel
+}
+
+// NewLeveledBackend returns backend wrapped with a minimum LogLevel.
+func NewLeveledBackend(backend Backend, level LogLevel) *LeveledBackend {
+	return &LeveledBackend{backend: backend, level: level}
+}
+
+// SetLevel changes the minimum LogLevel for this backend.
+func (b *LeveledBackend) SetLevel(level LogLevel) {
+	b.level = level
+}
+
+// Log forwards info to the wrapped Backend if level is at or above the
+// configured minimum.
+func (b *LeveledBackend) Log(level LogLevel, info *Info) error {
+	if b.level != 0 && b.level < level {
+		return nil
+	}
+	return b.backend.Log(level, info)
+}
+
+// Flush drains the wrapped Backend if it buffers asynchronously, so
+// Fatal/Panic also drain an async backend registered through a
+// LeveledBackend.
+func (b *LeveledBackend) Flush(timeout time.Duration) bool {
+	if f, ok := b.backend.(flusher); ok {
+		return f.Flush(timeout)
+	}
+	return true
+}
+
+// workerBackend adapts the original single-Minion Worker to the Backend
+// interface, so it keeps working unchanged as a Logger's "default" backend.
+type workerBackend struct {
+	worker *Worker
+}
+
+// NewWorkerBackend adapts worker to the Backend interface, e.g. to
+// register the result of NewAsyncWorker with Logger.AddBackend.
+func NewWorkerBackend(worker *Worker) Backend {
+	return &workerBackend{worker: worker}
+}
+
+func (b *workerBackend) Log(level LogLevel, info *Info) error {
+	return b.worker.Log(level, 2, info)
+}
+
+// Flush lets a Logger drain this backend's Worker (if it wraps an async
+// one) before Fatal/Panic exit the process.
+func (b *workerBackend) Flush(timeout time.Duration) bool {
+	return b.worker.Flush(timeout)
+}
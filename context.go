@@ -0,0 +1,89 @@
+package logger
+
+import "context"
+
+// loggerCtxKeyType is an unexported type so the key used by
+// WithContext/FromContext can never collide with a key from another
+// package.
+type loggerCtxKeyType struct{}
+
+var loggerCtxKey = loggerCtxKeyType{}
+
+// WithContext returns a copy of ctx carrying l, retrievable later with
+// FromContext. Intended for HTTP/gRPC middleware that wants to thread a
+// request-scoped Logger (see also Logger.Ctx) through a context.Context.
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey, l)
+}
+
+// FromContext returns the Logger previously stored in ctx by WithContext,
+// or nil if none was stored.
+func FromContext(ctx context.Context) *Logger {
+	l, _ := ctx.Value(loggerCtxKey).(*Logger)
+	return l
+}
+
+// ctxFieldKey is the context key type used for the well-known fields
+// Logger.Ctx extracts automatically.
+type ctxFieldKey string
+
+// Well-known context keys Logger.Ctx looks for; set them with
+// WithTraceID, WithSpanID and WithRequestID.
+const (
+	TraceIDKey   ctxFieldKey = "trace_id"
+	SpanIDKey    ctxFieldKey = "span_id"
+	RequestIDKey ctxFieldKey = "request_id"
+)
+
+// WithTraceID returns a copy of ctx carrying v as the trace ID, so a
+// later Logger.Ctx(ctx) (or the %{trace_id} format placeholder) picks it
+// up automatically.
+func WithTraceID(ctx context.Context, v string) context.Context {
+	return context.WithValue(ctx, TraceIDKey, v)
+}
+
+// WithSpanID returns a copy of ctx carrying v as the span ID.
+func WithSpanID(ctx context.Context, v string) context.Context {
+	return context.WithValue(ctx, SpanIDKey, v)
+}
+
+// WithRequestID returns a copy of ctx carrying v as the request ID.
+func WithRequestID(ctx context.Context, v string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, v)
+}
+
+func ctxValueString(ctx context.Context, key ctxFieldKey) string {
+	if ctx == nil {
+		return ""
+	}
+	v, _ := ctx.Value(key).(string)
+	return v
+}
+
+// Ctx returns a child Logger that carries ctx: the %{trace_id},
+// %{span_id}, %{request_id} and %{ctx:key} format placeholders resolve
+// against it, and whichever of the three well-known values are present
+// in ctx are also attached as structured fields (see Logger.With) for
+// JSONEncoder/LogfmtEncoder.
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	var kv []interface{}
+	if v := ctxValueString(ctx, TraceIDKey); v != "" {
+		kv = append(kv, "trace_id", v)
+	}
+	if v := ctxValueString(ctx, SpanIDKey); v != "" {
+		kv = append(kv, "span_id", v)
+	}
+	if v := ctxValueString(ctx, RequestIDKey); v != "" {
+		kv = append(kv, "request_id", v)
+	}
+
+	var child *Logger
+	if len(kv) > 0 {
+		child = l.With(kv...)
+	} else {
+		clone := *l
+		child = &clone
+	}
+	child.ctx = ctx
+	return child
+}
@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Encoder renders a log record into the exact bytes a Worker writes out,
+// including any color escape codes. Selecting a different Encoder on a
+// Worker changes its output format without touching anything else.
+type Encoder interface {
+	Encode(level LogLevel, info *Info) string
+}
+
+// TextEncoder renders records using the human-readable %{...} format this
+// package has always used, and is the default Encoder for every Worker.
+type TextEncoder struct {
+	format     string
+	timeFormat string
+	color      int
+}
+
+// NewTextEncoder returns a TextEncoder using format (parsed the same way
+// as Worker.SetFormat; the package default is used when format is empty)
+// and color (0 disables ANSI coloring). A %{time:layout} in format does
+// not reformat Info.Time, which the Logger has already rendered.
+func NewTextEncoder(format string, color int) *TextEncoder {
+	msgfmt, timefmt := defFmt, defTimeFmt
+	if format != "" {
+		msgfmt, timefmt = parseFormat(format)
+	}
+	return &TextEncoder{format: msgfmt, timeFormat: timefmt, color: color}
+}
+
+// Encode renders info as plain (or, if colored, ANSI-wrapped) text.
+func (e *TextEncoder) Encode(level LogLevel, info *Info) string {
+	msg := info.Output(e.format)
+	if e.color == 0 {
+		return msg
+	}
+	return colors[level] + msg + "\033[0m"
+}
+
+// JSONEncoder renders records as a single-line JSON object with a stable
+// field order: id, time, level, module, file, line, msg, then any
+// structured fields added via Logger.With/Infow et al.
+type JSONEncoder struct{}
+
+// NewJSONEncoder returns a JSONEncoder.
+func NewJSONEncoder() *JSONEncoder {
+	return &JSONEncoder{}
+}
+
+// Encode renders info as a single line of JSON, terminated with "\n".
+func (e *JSONEncoder) Encode(level LogLevel, info *Info) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONField(&buf, "id", info.ID, true)
+	writeJSONField(&buf, "time", info.Time, false)
+	writeJSONField(&buf, "level", info.logLevelString(), false)
+	writeJSONField(&buf, "module", info.Module, false)
+	writeJSONField(&buf, "file", info.Filename, false)
+	writeJSONField(&buf, "line", info.Line, false)
+	writeJSONField(&buf, "msg", info.Message, false)
+	for _, f := range info.Fields {
+		writeJSONField(&buf, f.Key, f.Value, false)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}, first bool) {
+	if !first {
+		buf.WriteByte(',')
+	}
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	buf.Write(v)
+}
+
+// LogfmtEncoder renders records as space-separated key=value pairs, in
+// the same field order as JSONEncoder.
+type LogfmtEncoder struct{}
+
+// NewLogfmtEncoder returns a LogfmtEncoder.
+func NewLogfmtEncoder() *LogfmtEncoder {
+	return &LogfmtEncoder{}
+}
+
+// Encode renders info as a single logfmt line, terminated with "\n".
+func (e *LogfmtEncoder) Encode(level LogLevel, info *Info) string {
+	var buf bytes.Buffer
+	writeLogfmtField(&buf, "id", info.ID, true)
+	writeLogfmtField(&buf, "time", info.Time, false)
+	writeLogfmtField(&buf, "level", info.logLevelString(), false)
+	writeLogfmtField(&buf, "module", info.Module, false)
+	writeLogfmtField(&buf, "file", info.Filename, false)
+	writeLogfmtField(&buf, "line", info.Line, false)
+	writeLogfmtField(&buf, "msg", info.Message, false)
+	for _, f := range info.Fields {
+		writeLogfmtField(&buf, f.Key, f.Value, false)
+	}
+	buf.WriteByte('\n')
+	return buf.String()
+}
+
+func writeLogfmtField(buf *bytes.Buffer, key string, value interface{}, first bool) {
+	if !first {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	s := fmt.Sprintf("%v", value)
+	if s == "" || strings.ContainsAny(s, " =\"") {
+		s = strconv.Quote(s)
+	}
+	buf.WriteString(s)
+}
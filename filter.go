@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"regexp"
+	"sync/atomic"
+)
+
+// Filter inspects (and may rewrite) an Info record before it reaches a
+// Backend. Returning ok=false drops the record entirely; no Backend
+// sees it and no error is reported to the caller.
+type Filter func(info *Info) (out *Info, ok bool)
+
+// RedactKeys returns a Filter that replaces the value of any structured
+// field (added via Logger.With or one of the *w methods) whose key is in
+// keys with "***". Common use: RedactKeys("password", "token").
+func RedactKeys(keys ...string) Filter {
+	redact := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		redact[k] = true
+	}
+	return func(info *Info) (*Info, bool) {
+		if len(info.Fields) == 0 {
+			return info, true
+		}
+		fields := make([]Field, len(info.Fields))
+		copy(fields, info.Fields)
+		changed := false
+		for i, f := range fields {
+			if redact[f.Key] {
+				fields[i].Value = "***"
+				changed = true
+			}
+		}
+		if !changed {
+			return info, true
+		}
+		out := *info
+		out.Fields = fields
+		return &out, true
+	}
+}
+
+// RedactRegex returns a Filter that replaces every match of re in the
+// record's Message with replacement (see regexp.ReplaceAllString).
+func RedactRegex(re *regexp.Regexp, replacement string) Filter {
+	return func(info *Info) (*Info, bool) {
+		message := re.ReplaceAllString(info.Message, replacement)
+		if message == info.Message {
+			return info, true
+		}
+		out := *info
+		out.Message = message
+		return &out, true
+	}
+}
+
+// SampleEvery returns a Filter that keeps one record out of every n and
+// drops the rest, for thinning out high-volume log lines. n < 1 is
+// treated as 1 (keep everything).
+func SampleEvery(n int) Filter {
+	if n < 1 {
+		n = 1
+	}
+	count := new(uint64)
+	return func(info *Info) (*Info, bool) {
+		c := atomic.AddUint64(count, 1)
+		return info, c%uint64(n) == 0
+	}
+}
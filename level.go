@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// levelRule is one "module=LEVEL" (or "module/*=LEVEL") clause parsed out
+// of a level spec by SetLevels.
+type levelRule struct {
+	module string
+	level  LogLevel
+	prefix bool // true when module came from a "module/*" wildcard clause
+}
+
+// moduleLevels is the global per-module level registry. Every Logger
+// created via New registers itself here so a single SetLevels call can
+// retune all of them at once.
+var moduleLevels = struct {
+	mu         sync.RWMutex
+	configured bool
+	def        LogLevel
+	rules      []levelRule
+	loggers    map[string][]*Logger
+	spec       string
+}{def: InfoLevel}
+
+// RegisterModule adds l to the global module-level registry so a later
+// SetLevels call can retune it by Module name. New calls this
+// automatically; it only needs to be called directly for Loggers built
+// by hand.
+func RegisterModule(l *Logger) {
+	moduleLevels.mu.Lock()
+	defer moduleLevels.mu.Unlock()
+	if moduleLevels.loggers == nil {
+		moduleLevels.loggers = map[string][]*Logger{}
+	}
+	moduleLevels.loggers[l.Module] = append(moduleLevels.loggers[l.Module], l)
+	if moduleLevels.configured {
+		l.SetLogLevel(resolveLevelLocked(l.Module))
+	}
+}
+
+// SetLevels parses a spec such as "DEBUG,net=INFO,db/*=WARNING" and
+// applies it to every registered Logger: a bare level name sets the
+// global default, and "module=LEVEL" or "module/*=LEVEL" clauses set (or,
+// with the trailing "/*", prefix-match) per-module overrides. Later
+// clauses for the same exact module win; the longest matching "/*"
+// wildcard wins among prefix clauses.
+func SetLevels(spec string) error {
+	def := LogLevel(-1)
+	var rules []levelRule
+	for _, tok := range strings.Split(spec, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		idx := strings.IndexByte(tok, '=')
+		if idx == -1 {
+			level, err := parseLevelName(tok)
+			if err != nil {
+				return err
+			}
+			def = level
+			continue
+		}
+		module, name := tok[:idx], tok[idx+1:]
+		level, err := parseLevelName(name)
+		if err != nil {
+			return err
+		}
+		rule := levelRule{module: module, level: level}
+		if strings.HasSuffix(module, "*") {
+			rule.prefix = true
+			rule.module = strings.TrimSuffix(module, "*")
+		}
+		rules = append(rules, rule)
+	}
+
+	moduleLevels.mu.Lock()
+	defer moduleLevels.mu.Unlock()
+	if def != -1 {
+		moduleLevels.def = def
+	}
+	moduleLevels.rules = rules
+	moduleLevels.spec = spec
+	moduleLevels.configured = true
+	for module, loggers := range moduleLevels.loggers {
+		level := resolveLevelLocked(module)
+		for _, l := range loggers {
+			l.SetLogLevel(level)
+		}
+	}
+	return nil
+}
+
+// GetLevel returns the LogLevel module currently resolves to under the
+// spec last passed to SetLevels (or the package default, InfoLevel, if
+// SetLevels has never been called).
+func GetLevel(module string) LogLevel {
+	moduleLevels.mu.RLock()
+	defer moduleLevels.mu.RUnlock()
+	return resolveLevelLocked(module)
+}
+
+// resolveLevelLocked must be called with moduleLevels.mu held.
+func resolveLevelLocked(module string) LogLevel {
+	for i := len(moduleLevels.rules) - 1; i >= 0; i-- {
+		if r := moduleLevels.rules[i]; !r.prefix && r.module == module {
+			return r.level
+		}
+	}
+	level := moduleLevels.def
+	bestLen := -1
+	for _, r := range moduleLevels.rules {
+		if r.prefix && strings.HasPrefix(module, r.module) && len(r.module) > bestLen {
+			level, bestLen = r.level, len(r.module)
+		}
+	}
+	return level
+}
+
+func parseLevelName(name string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "CRITICAL", "CRIT":
+		return CriticalLevel, nil
+	case "ERROR", "ERR":
+		return ErrorLevel, nil
+	case "WARNING", "WARN":
+		return WarningLevel, nil
+	case "NOTICE":
+		return NoticeLevel, nil
+	case "INFO":
+		return InfoLevel, nil
+	case "DEBUG":
+		return DebugLevel, nil
+	default:
+		return 0, fmt.Errorf("logger: unknown log level %q", name)
+	}
+}
+
+// LevelConfig implements flag.Value so a level spec (see SetLevels) can
+// be wired directly into a flag:
+//
+//	flag.Var(&logger.LevelConfig{}, "log-level", "per-module log levels, e.g. DEBUG,net=INFO")
+type LevelConfig struct{}
+
+// String returns the spec last passed to SetLevels.
+func (*LevelConfig) String() string {
+	moduleLevels.mu.RLock()
+	defer moduleLevels.mu.RUnlock()
+	return moduleLevels.spec
+}
+
+// Set parses and applies spec via SetLevels.
+func (*LevelConfig) Set(spec string) error {
+	return SetLevels(spec)
+}
+
+// LevelsHandler is an http.HandlerFunc for runtime log-level tuning: GET
+// returns the currently configured spec, POST/PUT applies a new one taken
+// from the "spec" query parameter or, if absent, the request body.
+func LevelsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		io.WriteString(w, (&LevelConfig{}).String())
+	case http.MethodPost, http.MethodPut:
+		spec := r.URL.Query().Get("spec")
+		if spec == "" {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			spec = strings.TrimSpace(string(body))
+		}
+		if err := SetLevels(spec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
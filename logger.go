@@ -3,18 +3,36 @@ package logger
 
 // Import packages
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path"
+	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// Name of the backend a Logger falls back to when it is created via New,
+// wrapping the single Minion Worker that has always existed.
+const defaultBackendName = "default"
+
+// ctxPlaceholderMarker/ctxPlaceholderEnd bracket the raw key of a
+// %{ctx:key} placeholder inside a parsed msgfmt, so Output can resolve it
+// against a record's Context after the rest of the format has been
+// rendered with fmt.Sprintf (an arbitrary key can't be mapped to a fixed
+// printf verb).
+const (
+	ctxPlaceholderMarker = "\x00ctx:"
+	ctxPlaceholderEnd    = "\x00"
+)
+
+var ctxPlaceholderRe = regexp.MustCompile(ctxPlaceholderMarker + `[^\x00]*` + ctxPlaceholderEnd)
+
 var (
 	// Map for the various codes of colors
 	colors map[LogLevel]string
@@ -65,6 +83,12 @@ type Worker struct {
 	format     string
 	timeFormat string
 	level      LogLevel
+	encoder    Encoder
+
+	filtersMu sync.RWMutex
+	filters   []Filter
+
+	async *asyncWriter
 }
 
 // Info class, Contains all the info on what has to logged, time is the current time, Module is the specific module
@@ -78,14 +102,45 @@ type Info struct {
 	Line     int
 	Filename string
 	Message  string
+	// Fields holds structured key/value pairs added via Logger.With or
+	// one of the *w methods (Infow, Errorw, ...); ignored by TextEncoder.
+	Fields []Field
+	// Context, set via Logger.Ctx, backs the %{trace_id}, %{span_id},
+	// %{request_id} and %{ctx:key} format placeholders.
+	Context context.Context
+	// Function is the full package.Function (or package.(*Type).Method)
+	// name of the call site, backing %{function}/%{shortfunc}.
+	Function string
 	//format   string
 }
 
+// backendRegistry holds the named Backends a Logger (and all Loggers
+// derived from it via With) dispatches records to, guarded by a single
+// mutex shared across every derived Logger.
+type backendRegistry struct {
+	mu       sync.RWMutex
+	backends map[string]Backend
+}
+
+// filterChain holds the Filters a Logger (and all Loggers derived from it
+// via With) runs every record through before it reaches any Backend,
+// guarded by a single mutex shared across every derived Logger.
+type filterChain struct {
+	mu      sync.RWMutex
+	filters []Filter
+}
+
 // Logger class that is an interface to user to log messages, Module is the module for which we are testing
 // worker is variable of Worker class that is used in bottom layers to log the message
 type Logger struct {
-	Module string
-	worker *Worker
+	Module     string
+	worker     *Worker
+	fields     []Field
+	ctx        context.Context
+	callerSkip int
+
+	registry *backendRegistry
+	filters  *filterChain
 }
 
 // init pkg
@@ -97,17 +152,28 @@ func init() {
 // Output returns a proper string to be outputted for a particular info
 func (r *Info) Output(format string) string {
 	msg := fmt.Sprintf(format,
-		r.ID,               // %[1] // %{id}
-		r.Time,             // %[2] // %{time[:fmt]}
-		r.Module,           // %[3] // %{module}
-		r.Filename,         // %[4] // %{filename}
-		r.Line,             // %[5] // %{line}
-		r.logLevelString(), // %[6] // %{level}
-		r.Message,          // %[7] // %{message}
+		r.ID,                                    // %[1] // %{id}
+		r.Time,                                  // %[2] // %{time[:fmt]}
+		r.Module,                                // %[3] // %{module}
+		r.Filename,                              // %[4] // %{filename}
+		r.Line,                                  // %[5] // %{line}
+		r.logLevelString(),                      // %[6] // %{level}
+		r.Message,                               // %[7] // %{message}
+		ctxValueString(r.Context, TraceIDKey),   // %[8] // %{trace_id}
+		ctxValueString(r.Context, SpanIDKey),    // %[9] // %{span_id}
+		ctxValueString(r.Context, RequestIDKey), // %[10] // %{request_id}
+		r.Function,                              // %[11] // %{function}
+		shortFuncName(r.Function),               // %[12] // %{shortfunc}
 	)
 	// Ignore printf errors if len(args) > len(verbs)
 	if i := strings.LastIndex(msg, "%!(EXTRA"); i != -1 {
-		return msg[:i]
+		msg = msg[:i]
+	}
+	if strings.Contains(msg, ctxPlaceholderMarker) {
+		msg = ctxPlaceholderRe.ReplaceAllStringFunc(msg, func(tok string) string {
+			key := tok[len(ctxPlaceholderMarker) : len(tok)-len(ctxPlaceholderEnd)]
+			return ctxValueString(r.Context, ctxFieldKey(key))
+		})
 	}
 	return msg
 }
@@ -136,12 +202,20 @@ func parseFormat(format string) (msgfmt, timefmt string) {
 						continue
 					}
 					// get verb and arg
-					verb, arg := ph2verb(format[:jdx+1])
-					msgfmt += verb
-					// check if verb is time
-					// here you can handle args for other verbs
-					if verb == `%[2]s` && arg != "" /* %{time} */ {
-						timefmt = arg
+					raw := format[:jdx+1]
+					if key, ok := ctxPlaceholderKey(raw); ok {
+						// %{ctx:key} can't be resolved to a fixed printf
+						// verb (key is arbitrary), so it's kept as a
+						// literal marker and substituted later in Output.
+						msgfmt += ctxPlaceholderMarker + key + ctxPlaceholderEnd
+					} else {
+						verb, arg := ph2verb(raw)
+						msgfmt += verb
+						// check if verb is time
+						// here you can handle args for other verbs
+						if verb == `%[2]s` && arg != "" /* %{time} */ {
+							timefmt = arg
+						}
 					}
 					format = format[jdx+1:]
 				} else {
@@ -180,7 +254,9 @@ func ph2verb(ph string) (verb string, arg string) {
 // NewWorker returns an instance of worker class, prefix is the string attached to every log,
 // flag determine the log params, color parameters verifies whether we need colored outputs or not
 func NewWorker(prefix string, flag int, color int, out io.Writer) *Worker {
-	return &Worker{Minion: log.New(out, prefix, flag), Color: color, format: defFmt, timeFormat: defTimeFmt}
+	w := &Worker{Minion: log.New(out, prefix, flag), Color: color, format: defFmt, timeFormat: defTimeFmt}
+	w.encoder = &TextEncoder{format: w.format, timeFormat: w.timeFormat, color: color}
+	return w
 }
 
 // SetDefaultFormat sets default format for the message
@@ -191,6 +267,26 @@ func SetDefaultFormat(format string) {
 // SetFormat for the worker sets the format for the worker
 func (w *Worker) SetFormat(format string) {
 	w.format, w.timeFormat = parseFormat(format)
+	if te, ok := w.encoder.(*TextEncoder); ok {
+		te.format, te.timeFormat = w.format, w.timeFormat
+	}
+}
+
+// SetColor enables (non-zero) or disables (0) ANSI color output for the
+// worker.
+func (w *Worker) SetColor(color int) {
+	w.Color = color
+	if te, ok := w.encoder.(*TextEncoder); ok {
+		te.color = color
+	}
+}
+
+// SetEncoder selects the Encoder used to render records for this worker,
+// e.g. NewJSONEncoder() or NewLogfmtEncoder() instead of the default
+// TextEncoder. Switching encoders does not affect SetFormat/SetColor,
+// which only apply while the default TextEncoder is in use.
+func (w *Worker) SetEncoder(e Encoder) {
+	w.encoder = e
 }
 
 // SetFormat for teh logger sets format for the logger
@@ -198,6 +294,14 @@ func (l *Logger) SetFormat(format string) {
 	l.worker.SetFormat(format)
 }
 
+// SetEncoder selects the Encoder used to render records on l's own
+// worker (the "default" backend registered by New), e.g. NewJSONEncoder()
+// or NewLogfmtEncoder() instead of the default TextEncoder. It does not
+// affect any other Backend added via AddBackend.
+func (l *Logger) SetEncoder(e Encoder) {
+	l.worker.SetEncoder(e)
+}
+
 // SetLogLevel for the worker sets the log level for the worker
 func (w *Worker) SetLogLevel(level LogLevel) {
 	w.level = level
@@ -211,19 +315,31 @@ func (l *Logger) SetLogLevel(level LogLevel) {
 // Log is a function of Worker class to log a string based on level
 func (w *Worker) Log(level LogLevel, calldepth int, info *Info) error {
 
-	if w.level < level {
+	if w.level != 0 && w.level < level {
 		return nil
 	}
 
-	if w.Color != 0 {
-		buf := &bytes.Buffer{}
-		buf.Write([]byte(colors[level]))
-		buf.Write([]byte(info.Output(w.format)))
-		buf.Write([]byte("\033[0m"))
-		return w.Minion.Output(calldepth+1, buf.String())
+	w.filtersMu.RLock()
+	filters := append([]Filter(nil), w.filters...)
+	w.filtersMu.RUnlock()
+	for _, f := range filters {
+		var ok bool
+		if info, ok = f(info); !ok {
+			return nil
+		}
 	}
-	return w.Minion.Output(calldepth+1, info.Output(w.format))
 
+	return w.Minion.Output(calldepth+1, w.encoder.Encode(level, info))
+}
+
+// AddFilter registers f on the worker; every subsequent Log call runs
+// through it (in registration order) before being rendered, in addition
+// to any Filters registered on the owning Logger. Safe for concurrent
+// use.
+func (w *Worker) AddFilter(f Filter) {
+	w.filtersMu.Lock()
+	defer w.filtersMu.Unlock()
+	w.filters = append(w.filters, f)
 }
 
 // Returns a proper string to output for colored logging
@@ -246,16 +362,44 @@ func initColors() {
 // Initializes the map of placeholders
 func initFormatPlaceholders() {
 	phfs = map[string]string{
-		"%{id}":       "%[1]d",
-		"%{time}":     "%[2]s",
-		"%{module}":   "%[3]s",
-		"%{filename}": "%[4]s",
-		"%{file}":     "%[4]s",
-		"%{line}":     "%[5]d",
-		"%{level}":    "%[6]s",
-		"%{lvl}":      "%.3[6]s",
-		"%{message}":  "%[7]s",
+		"%{id}":         "%[1]d",
+		"%{time}":       "%[2]s",
+		"%{module}":     "%[3]s",
+		"%{filename}":   "%[4]s",
+		"%{file}":       "%[4]s",
+		"%{line}":       "%[5]d",
+		"%{level}":      "%[6]s",
+		"%{lvl}":        "%.3[6]s",
+		"%{message}":    "%[7]s",
+		"%{trace_id}":   "%[8]s",
+		"%{span_id}":    "%[9]s",
+		"%{request_id}": "%[10]s",
+		"%{function}":   "%[11]s",
+		"%{shortfunc}":  "%[12]s",
+	}
+}
+
+// shortFuncName trims a full package.Function (as reported by
+// runtime.Frame.Function) down to the bare function/method name, e.g.
+// "github.com/foo/bar.(*Thing).Method" -> "Method".
+func shortFuncName(function string) string {
+	if idx := strings.LastIndex(function, "/"); idx != -1 {
+		function = function[idx+1:]
+	}
+	if idx := strings.LastIndex(function, "."); idx != -1 {
+		function = function[idx+1:]
 	}
+	return function
+}
+
+// ctxPlaceholderKey reports whether raw is a "%{ctx:key}" placeholder and,
+// if so, returns key.
+func ctxPlaceholderKey(raw string) (key string, ok bool) {
+	const prefix = "%{ctx:"
+	if !strings.HasPrefix(raw, prefix) || !strings.HasSuffix(raw, "}") {
+		return "", false
+	}
+	return raw[len(prefix) : len(raw)-1], true
 }
 
 // New returns a new instance of logger class, module is the specific module for which we are logging
@@ -285,19 +429,72 @@ func New(args ...interface{}) (*Logger, error) {
 	}
 	newWorker := NewWorker("", 0, color, out)
 	newWorker.SetLogLevel(level)
-	return &Logger{Module: module, worker: newWorker}, nil
+	l := &Logger{
+		Module: module,
+		worker: newWorker,
+		registry: &backendRegistry{
+			backends: map[string]Backend{defaultBackendName: &workerBackend{worker: newWorker}},
+		},
+		filters: &filterChain{},
+	}
+	RegisterModule(l)
+	return l, nil
+}
+
+// AddBackend registers b under name so Logger also dispatches records to
+// it, in addition to the default Minion-backed backend. Adding a backend
+// under an existing name replaces it. Also visible to any Logger derived
+// from l via With. Safe for concurrent use.
+func (l *Logger) AddBackend(name string, b Backend) {
+	l.registry.mu.Lock()
+	defer l.registry.mu.Unlock()
+	if l.registry.backends == nil {
+		l.registry.backends = map[string]Backend{}
+	}
+	l.registry.backends[name] = b
+}
+
+// RemoveBackend unregisters the backend added under name, including
+// defaultBackendName. Safe for concurrent use.
+func (l *Logger) RemoveBackend(name string) {
+	l.registry.mu.Lock()
+	defer l.registry.mu.Unlock()
+	delete(l.registry.backends, name)
+}
+
+// AddFilter registers f on the logger; every subsequent log call runs
+// through it (in registration order) before reaching any Backend,
+// dropping the record if f returns ok=false. Also visible to any Logger
+// derived from l via With. Safe for concurrent use.
+func (l *Logger) AddFilter(f Filter) {
+	l.filters.mu.Lock()
+	defer l.filters.mu.Unlock()
+	l.filters.filters = append(l.filters.filters, f)
 }
 
 // Log commnand is the function available to user to log message, lvl specifies
 // the degree of the messagethe user wants to log, message is the info user wants to log
 func (l *Logger) Log(lvl LogLevel, message string) {
-	l.logInternal(lvl, message, 2)
+	l.logInternal(lvl, message, 2, nil)
 }
 
-func (l *Logger) logInternal(lvl LogLevel, message string, pos int) {
+func (l *Logger) logInternal(lvl LogLevel, message string, pos int, extra []Field) {
 	//var formatString string = "#%d %s [%s] %s:%d ▶ %.3s %s"
+	pos += l.callerSkip
 	_, filename, line, _ := runtime.Caller(pos)
 	filename = path.Base(filename)
+
+	var function string
+	pcs := make([]uintptr, 1)
+	if n := runtime.Callers(pos+1, pcs); n > 0 {
+		frame, _ := runtime.CallersFrames(pcs[:n]).Next()
+		function = frame.Function
+	}
+
+	fields := l.fields
+	if len(extra) > 0 {
+		fields = append(append([]Field{}, l.fields...), extra...)
+	}
 	info := &Info{
 		ID:       atomic.AddUint64(&logNo, 1),
 		Time:     time.Now().Format(l.worker.timeFormat),
@@ -306,93 +503,137 @@ func (l *Logger) logInternal(lvl LogLevel, message string, pos int) {
 		Message:  message,
 		Filename: filename,
 		Line:     line,
+		Function: function,
+		Fields:   fields,
+		Context:  l.ctx,
 		//format:   formatString,
 	}
-	l.worker.Log(lvl, 2, info)
+
+	l.filters.mu.RLock()
+	filters := append([]Filter(nil), l.filters.filters...)
+	l.filters.mu.RUnlock()
+	for _, f := range filters {
+		var ok bool
+		if info, ok = f(info); !ok {
+			return
+		}
+	}
+
+	l.registry.mu.RLock()
+	backends := make([]Backend, 0, len(l.registry.backends))
+	for _, b := range l.registry.backends {
+		backends = append(backends, b)
+	}
+	l.registry.mu.RUnlock()
+
+	for _, b := range backends {
+		b.Log(lvl, info)
+	}
 }
 
 // Fatal is just like func l.Critical logger except that it is followed by exit to program
 func (l *Logger) Fatal(message string) {
-	l.logInternal(CriticalLevel, message, 2)
+	l.logInternal(CriticalLevel, message, 2, nil)
+	l.flushAll(fatalFlushTimeout)
 	os.Exit(1)
 }
 
 // Fatalf is just like func l.CriticalF logger except that it is followed by exit to program
 func (l *Logger) Fatalf(format string, a ...interface{}) {
-	l.logInternal(CriticalLevel, fmt.Sprintf(format, a...), 2)
+	l.logInternal(CriticalLevel, fmt.Sprintf(format, a...), 2, nil)
+	l.flushAll(fatalFlushTimeout)
 	os.Exit(1)
 }
 
 // Panic is just like func l.Critical except that it is followed by a call to panic
 func (l *Logger) Panic(message string) {
-	l.logInternal(CriticalLevel, message, 2)
+	l.logInternal(CriticalLevel, message, 2, nil)
+	l.flushAll(fatalFlushTimeout)
 	panic(message)
 }
 
 // Panicf is just like func l.CriticalF except that it is followed by a call to panic
 func (l *Logger) Panicf(format string, a ...interface{}) {
-	l.logInternal(CriticalLevel, fmt.Sprintf(format, a...), 2)
+	l.logInternal(CriticalLevel, fmt.Sprintf(format, a...), 2, nil)
+	l.flushAll(fatalFlushTimeout)
 	panic(fmt.Sprintf(format, a...))
 }
 
+// flushAll drains every registered Backend that buffers asynchronously
+// (see NewAsyncWorker), so Fatal/Panic don't exit the process or unwind
+// the stack while records are still queued.
+func (l *Logger) flushAll(timeout time.Duration) {
+	l.registry.mu.RLock()
+	backends := make([]Backend, 0, len(l.registry.backends))
+	for _, b := range l.registry.backends {
+		backends = append(backends, b)
+	}
+	l.registry.mu.RUnlock()
+	for _, b := range backends {
+		if f, ok := b.(flusher); ok {
+			f.Flush(timeout)
+		}
+	}
+}
+
 // Critical logs a message at a Critical Level
 func (l *Logger) Critical(message string) {
-	l.logInternal(CriticalLevel, message, 2)
+	l.logInternal(CriticalLevel, message, 2, nil)
 }
 
 // Criticalf logs a message at Critical level using the same syntax and options as fmt.Printf
 func (l *Logger) Criticalf(format string, a ...interface{}) {
-	l.logInternal(CriticalLevel, fmt.Sprintf(format, a...), 2)
+	l.logInternal(CriticalLevel, fmt.Sprintf(format, a...), 2, nil)
 }
 
 // Error logs a message at Error level
 func (l *Logger) Error(message string) {
-	l.logInternal(ErrorLevel, message, 2)
+	l.logInternal(ErrorLevel, message, 2, nil)
 }
 
 // Errorf logs a message at Error level using the same syntax and options as fmt.Printf
 func (l *Logger) Errorf(format string, a ...interface{}) {
-	l.logInternal(ErrorLevel, fmt.Sprintf(format, a...), 2)
+	l.logInternal(ErrorLevel, fmt.Sprintf(format, a...), 2, nil)
 }
 
 // Warning logs a message at Warning level
 func (l *Logger) Warning(message string) {
-	l.logInternal(WarningLevel, message, 2)
+	l.logInternal(WarningLevel, message, 2, nil)
 }
 
 // Warningf logs a message at Warning level using the same syntax and options as fmt.Printf
 func (l *Logger) Warningf(format string, a ...interface{}) {
-	l.logInternal(WarningLevel, fmt.Sprintf(format, a...), 2)
+	l.logInternal(WarningLevel, fmt.Sprintf(format, a...), 2, nil)
 }
 
 // Notice logs a message at Notice level
 func (l *Logger) Notice(message string) {
-	l.logInternal(NoticeLevel, message, 2)
+	l.logInternal(NoticeLevel, message, 2, nil)
 }
 
 // Noticef logs a message at Notice level using the same syntax and options as fmt.Printf
 func (l *Logger) Noticef(format string, a ...interface{}) {
-	l.logInternal(NoticeLevel, fmt.Sprintf(format, a...), 2)
+	l.logInternal(NoticeLevel, fmt.Sprintf(format, a...), 2, nil)
 }
 
 // Info logs a message at Info level
 func (l *Logger) Info(message string) {
-	l.logInternal(InfoLevel, message, 2)
+	l.logInternal(InfoLevel, message, 2, nil)
 }
 
 // Infof logs a message at Info level using the same syntax and options as fmt.Printf
 func (l *Logger) Infof(format string, a ...interface{}) {
-	l.logInternal(InfoLevel, fmt.Sprintf(format, a...), 2)
+	l.logInternal(InfoLevel, fmt.Sprintf(format, a...), 2, nil)
 }
 
 // Debug logs a message at Debug level
 func (l *Logger) Debug(message string) {
-	l.logInternal(DebugLevel, message, 2)
+	l.logInternal(DebugLevel, message, 2, nil)
 }
 
-//Debugf logs a message at Debug level using the same syntax and options as fmt.Printf
+// Debugf logs a message at Debug level using the same syntax and options as fmt.Printf
 func (l *Logger) Debugf(format string, a ...interface{}) {
-	l.logInternal(DebugLevel, fmt.Sprintf(format, a...), 2)
+	l.logInternal(DebugLevel, fmt.Sprintf(format, a...), 2, nil)
 }
 
 // StackAsError prints this goroutine's execution stack as an error with an optional message at the begining
@@ -401,7 +642,7 @@ func (l *Logger) StackAsError(message string) {
 		message = "Stack info"
 	}
 	message += "\n"
-	l.logInternal(ErrorLevel, message+Stack(), 2)
+	l.logInternal(ErrorLevel, message+Stack(), 2, nil)
 }
 
 // StackAsCritical prints this goroutine's execution stack as critical with an optional message at the begining
@@ -410,14 +651,19 @@ func (l *Logger) StackAsCritical(message string) {
 		message = "Stack info"
 	}
 	message += "\n"
-	l.logInternal(CriticalLevel, message+Stack(), 2)
+	l.logInternal(CriticalLevel, message+Stack(), 2, nil)
 }
 
 // Stack returns a string with the execution stack for this goroutine
 func Stack() string {
-	buf := make([]byte, 1000000)
-	runtime.Stack(buf, false)
-	return string(buf)
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
 }
 
 // Returns the loglevel as string
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+)
+
+// Field is a single structured key/value pair attached to an Info record,
+// carried either via Logger.With or passed inline to one of the *w
+// methods (Infow, Errorw, ...).
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// fieldsFromKV turns alternating key, value arguments into Fields. An odd
+// trailing key is kept with a nil value.
+func fieldsFromKV(kv []interface{}) []Field {
+	if len(kv) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", kv[i])
+		}
+		var value interface{}
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		fields = append(fields, Field{Key: key, Value: value})
+	}
+	return fields
+}
+
+// With returns a child Logger that carries keysAndValues (alternating
+// key, value pairs) as structured fields on every subsequent log call,
+// in addition to any fields l itself already carries. The child shares
+// l's worker and backends.
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	fields := make([]Field, 0, len(l.fields)+(len(keysAndValues)+1)/2)
+	fields = append(fields, l.fields...)
+	fields = append(fields, fieldsFromKV(keysAndValues)...)
+	return &Logger{
+		Module:     l.Module,
+		worker:     l.worker,
+		registry:   l.registry,
+		filters:    l.filters,
+		fields:     fields,
+		ctx:        l.ctx,
+		callerSkip: l.callerSkip,
+	}
+}
+
+// WithCallerSkip returns a child Logger that ascends n additional stack
+// frames before resolving the %{file}, %{line}, %{function} and
+// %{shortfunc} format placeholders, so a logging wrapper built on top of
+// this package can correct for its own frame(s) without forking it.
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	clone := *l
+	clone.callerSkip = l.callerSkip + n
+	return &clone
+}
+
+// Criticalw logs a message at Critical level along with structured
+// key/value pairs (alternating key, value).
+func (l *Logger) Criticalw(message string, kv ...interface{}) {
+	l.logInternal(CriticalLevel, message, 2, fieldsFromKV(kv))
+}
+
+// Errorw logs a message at Error level along with structured key/value
+// pairs (alternating key, value).
+func (l *Logger) Errorw(message string, kv ...interface{}) {
+	l.logInternal(ErrorLevel, message, 2, fieldsFromKV(kv))
+}
+
+// Warningw logs a message at Warning level along with structured
+// key/value pairs (alternating key, value).
+func (l *Logger) Warningw(message string, kv ...interface{}) {
+	l.logInternal(WarningLevel, message, 2, fieldsFromKV(kv))
+}
+
+// Noticew logs a message at Notice level along with structured key/value
+// pairs (alternating key, value).
+func (l *Logger) Noticew(message string, kv ...interface{}) {
+	l.logInternal(NoticeLevel, message, 2, fieldsFromKV(kv))
+}
+
+// Infow logs a message at Info level along with structured key/value
+// pairs (alternating key, value).
+func (l *Logger) Infow(message string, kv ...interface{}) {
+	l.logInternal(InfoLevel, message, 2, fieldsFromKV(kv))
+}
+
+// Debugw logs a message at Debug level along with structured key/value
+// pairs (alternating key, value).
+func (l *Logger) Debugw(message string, kv ...interface{}) {
+	l.logInternal(DebugLevel, message, 2, fieldsFromKV(kv))
+}
+
+// Fatalw is just like Criticalw except that it is followed by exit to
+// program.
+func (l *Logger) Fatalw(message string, kv ...interface{}) {
+	l.logInternal(CriticalLevel, message, 2, fieldsFromKV(kv))
+	l.flushAll(fatalFlushTimeout)
+	os.Exit(1)
+}
+
+// Panicw is just like Criticalw except that it is followed by a call to
+// panic.
+func (l *Logger) Panicw(message string, kv ...interface{}) {
+	l.logInternal(CriticalLevel, message, 2, fieldsFromKV(kv))
+	l.flushAll(fatalFlushTimeout)
+	panic(message)
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+// +build !windows
+
+package logger
+
+import "log/syslog"
+
+// SyslogBackend writes log records to the local syslog daemon.
+type SyslogBackend struct {
+	writer *syslog.Writer
+	format string
+}
+
+// NewSyslogBackend returns a Backend that forwards records to syslog,
+// tagged with tag (the package default format is used when format is
+// empty). Use syslog.Dial directly and wrap the result if a remote
+// syslog daemon is needed.
+func NewSyslogBackend(tag, format string) (*SyslogBackend, error) {
+	writer, err := syslog.New(syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	msgfmt := defFmt
+	if format != "" {
+		msgfmt, _ = parseFormat(format)
+	}
+	return &SyslogBackend{writer: writer, format: msgfmt}, nil
+}
+
+// Log writes info to syslog at the priority matching level.
+func (b *SyslogBackend) Log(level LogLevel, info *Info) error {
+	msg := info.Output(b.format)
+	switch level {
+	case CriticalLevel:
+		return b.writer.Crit(msg)
+	case ErrorLevel:
+		return b.writer.Err(msg)
+	case WarningLevel:
+		return b.writer.Warning(msg)
+	case NoticeLevel:
+		return b.writer.Notice(msg)
+	case InfoLevel:
+		return b.writer.Info(msg)
+	case DebugLevel:
+		return b.writer.Debug(msg)
+	default:
+		return b.writer.Info(msg)
+	}
+}
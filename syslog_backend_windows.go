@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package logger
+
+import "errors"
+
+// SyslogBackend is unavailable on windows; log/syslog is unix-only.
+type SyslogBackend struct{}
+
+// NewSyslogBackend always fails on windows.
+func NewSyslogBackend(tag, format string) (*SyslogBackend, error) {
+	return nil, errors.New("logger: syslog backend is not supported on windows")
+}
+
+// Log is a no-op, present only to satisfy the Backend interface.
+func (b *SyslogBackend) Log(level LogLevel, info *Info) error {
+	return nil
+}